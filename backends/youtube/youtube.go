@@ -0,0 +1,262 @@
+// Package youtube uploads newly detected videos to YouTube using the Data
+// API v3's resumable upload support. Each video's metadata comes from a
+// sidecar "<video>.meta.json" file using the same shape the youtubeuploader
+// tool expects, so existing metadata files can be reused as-is.
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Cdaprod/local-docker-detect-videos-app/storage"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	youtubeapi "google.golang.org/api/youtube/v3"
+)
+
+var (
+	clientSecretFile string
+	tokenFile        string
+	rateLimit        int64
+)
+
+// uploadChunkSize is how much of a video Videos.Insert reads per Media()
+// chunk. It also bounds the burst size of the shared rate limiter below:
+// WaitN rejects any request larger than the limiter's burst, so the burst
+// must cover the largest single read the uploader can hand rateLimitedReader.
+const uploadChunkSize = 1 << 23
+
+var (
+	limiterOnce sync.Once
+	limiter     *rate.Limiter
+)
+
+// sharedLimiter lazily builds the process-wide upload rate limiter. It's
+// shared across every concurrent upload so -rate bounds aggregate bandwidth
+// instead of being handed out fresh (and thus multiplied by -jobs) per call.
+func sharedLimiter() *rate.Limiter {
+	limiterOnce.Do(func() {
+		limiter = rate.NewLimiter(rate.Limit(rateLimit), uploadChunkSize)
+	})
+	return limiter
+}
+
+func init() {
+	flag.StringVar(&clientSecretFile, "youtube-client-secret", "client_secret.json", "Path to the YouTube OAuth2 client secret JSON")
+	flag.StringVar(&tokenFile, "youtube-token", "youtube-token.json", "Path to cache the YouTube OAuth2 token")
+	flag.Int64Var(&rateLimit, "rate", 0, "Upload rate limit in bytes/sec (0 = unlimited)")
+	storage.Register("youtube", open)
+}
+
+// Meta mirrors the per-video metadata sidecar convention used by
+// youtubeuploader: a <video>.meta.json file living next to the video file.
+type Meta struct {
+	Title         string   `json:"title"`
+	Description   string   `json:"description"`
+	Tags          []string `json:"tags"`
+	CategoryID    string   `json:"categoryId"`
+	PrivacyStatus string   `json:"privacyStatus"`
+	RecordingDate string   `json:"recordingDate"`
+	PlaylistIDs   []string `json:"playlistIds"`
+}
+
+func loadMeta(videoPath string) (Meta, error) {
+	metaPath := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".meta.json"
+	var m Meta
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return m, fmt.Errorf("youtube: reading %s: %w", metaPath, err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("youtube: parsing %s: %w", metaPath, err)
+	}
+	return m, nil
+}
+
+// Uploader drives resumable uploads against the YouTube Data API v3.
+type Uploader struct {
+	svc *youtubeapi.Service
+}
+
+func open(ctx context.Context, _ *url.URL) (storage.Uploader, error) {
+	secret, err := os.ReadFile(clientSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("youtube: reading client secret: %w", err)
+	}
+	config, err := google.ConfigFromJSON(secret, youtubeapi.YoutubeUploadScope)
+	if err != nil {
+		return nil, fmt.Errorf("youtube: parsing client secret: %w", err)
+	}
+	client, err := authorizedClient(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := youtubeapi.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("youtube: creating service: %w", err)
+	}
+	return &Uploader{svc: svc}, nil
+}
+
+// authorizedClient returns an http.Client for config, reusing a cached token
+// from tokenFile when present and otherwise running the interactive OAuth2
+// flow once and caching the result.
+func authorizedClient(ctx context.Context, config *oauth2.Config) (*http.Client, error) {
+	tok, err := tokenFromFile(tokenFile)
+	if err != nil {
+		tok, err = tokenFromWeb(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveToken(tokenFile, tok); err != nil {
+			return nil, fmt.Errorf("youtube: caching token: %w", err)
+		}
+	}
+	return config.Client(ctx, tok), nil
+}
+
+func tokenFromFile(path string) (*oauth2.Token, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tok := &oauth2.Token{}
+	return tok, json.NewDecoder(f).Decode(tok)
+}
+
+func saveToken(path string, tok *oauth2.Token) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(tok)
+}
+
+func tokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	fmt.Printf("Go to the following link in your browser, then paste the authorization code:\n%v\n", authURL)
+
+	var code string
+	if _, err := fmt.Scan(&code); err != nil {
+		return nil, fmt.Errorf("youtube: reading authorization code: %w", err)
+	}
+	return config.Exchange(ctx, code)
+}
+
+// Upload uploads localPath as a new YouTube video and returns its video ID.
+func (u *Uploader) Upload(ctx context.Context, localPath, key string) (string, error) {
+	meta, err := loadMeta(localPath)
+	if err != nil {
+		return "", err
+	}
+
+	video := &youtubeapi.Video{
+		Snippet: &youtubeapi.VideoSnippet{
+			Title:       meta.Title,
+			Description: meta.Description,
+			Tags:        meta.Tags,
+			CategoryId:  meta.CategoryID,
+		},
+		Status: &youtubeapi.VideoStatus{PrivacyStatus: meta.PrivacyStatus},
+		RecordingDetails: &youtubeapi.VideoRecordingDetails{
+			RecordingDate: meta.RecordingDate,
+		},
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if storage.OnStart != nil {
+		storage.OnStart(key, info.Size())
+	}
+
+	var result *youtubeapi.Video
+	err = storage.WithRetry(ctx, 5, func() error {
+		if _, serr := f.Seek(0, io.SeekStart); serr != nil {
+			return serr
+		}
+		var reader io.Reader = f
+		if rateLimit > 0 {
+			reader = &rateLimitedReader{r: reader, limiter: sharedLimiter()}
+		}
+		reader = storage.InstrumentReader(key, info.Size(), reader)
+
+		res, ierr := u.svc.Videos.Insert([]string{"snippet", "status", "recordingDetails"}, video).
+			Media(reader, googleapi.ChunkSize(uploadChunkSize)).
+			Do()
+		if ierr != nil {
+			return ierr
+		}
+		result = res
+		return nil
+	})
+	if storage.OnDone != nil {
+		storage.OnDone(key, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("youtube: uploading %s: %w", localPath, err)
+	}
+
+	for _, playlistID := range meta.PlaylistIDs {
+		_, perr := u.svc.PlaylistItems.Insert([]string{"snippet"}, &youtubeapi.PlaylistItem{
+			Snippet: &youtubeapi.PlaylistItemSnippet{
+				PlaylistId: playlistID,
+				ResourceId: &youtubeapi.ResourceId{Kind: "youtube#video", VideoId: result.Id},
+			},
+		}).Do()
+		if perr != nil {
+			fmt.Printf("youtube: failed to add %s to playlist %s: %v\n", result.Id, playlistID, perr)
+		}
+	}
+
+	return result.Id, nil
+}
+
+// RemoteURL implements storage.Locator.
+func (u *Uploader) RemoteURL(id string) string {
+	return "https://youtu.be/" + id
+}
+
+func (u *Uploader) Close() error {
+	return nil
+}
+
+// rateLimitedReader throttles reads to honor the -rate flag so uploads don't
+// saturate the link a camera card is being copied over at the same time.
+type rateLimitedReader struct {
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.limiter.WaitN(context.Background(), n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
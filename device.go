@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// removableDevice is one mounted volume a platform backend has identified as
+// removable media, along with whatever label it reports.
+type removableDevice struct {
+	Mountpoint string
+	Label      string
+}
+
+// removableDevices is implemented per-OS: device_linux.go, device_darwin.go,
+// and device_windows.go each answer "what's actually removable media here?"
+// using the mechanism that platform exposes for it.
+
+// DetectDevice returns the mountpoint of the first removable device found,
+// filtered to one whose label matches -label when that flag is set.
+func DetectDevice() (string, error) {
+	devices, err := removableDevices()
+	if err != nil {
+		return "", err
+	}
+
+	for _, d := range devices {
+		if deviceLabel == "" || strings.EqualFold(d.Label, deviceLabel) {
+			return d.Mountpoint, nil
+		}
+	}
+
+	if deviceLabel != "" {
+		return "", fmt.Errorf("no removable device labeled %q detected", deviceLabel)
+	}
+	return "", fmt.Errorf("no removable device detected")
+}
+
+// WatchForDevice polls every interval until a matching removable device
+// shows up, so -watch lets a user start the tool and then plug the card in.
+func WatchForDevice(ctx context.Context, interval time.Duration) (string, error) {
+	if mnt, err := DetectDevice(); err == nil {
+		return mnt, nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+			if mnt, err := DetectDevice(); err == nil {
+				return mnt, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"howett.net/plist"
+)
+
+// removableDevices enumerates /Volumes and asks diskutil whether each one is
+// removable media, since macOS doesn't expose anything like Linux's
+// /sys/block/<dev>/removable directly to userspace.
+func removableDevices() ([]removableDevice, error) {
+	entries, err := os.ReadDir("/Volumes")
+	if err != nil {
+		return nil, fmt.Errorf("error listing /Volumes: %v", err)
+	}
+
+	var devices []removableDevice
+	for _, entry := range entries {
+		mountpoint := filepath.Join("/Volumes", entry.Name())
+
+		out, err := exec.Command("diskutil", "info", "-plist", mountpoint).Output()
+		if err != nil {
+			continue
+		}
+
+		var info struct {
+			RemovableMedia bool `plist:"RemovableMedia"`
+		}
+		if _, err := plist.Unmarshal(out, &info); err != nil || !info.RemovableMedia {
+			continue
+		}
+
+		devices = append(devices, removableDevice{Mountpoint: mountpoint, Label: entry.Name()})
+	}
+	return devices, nil
+}
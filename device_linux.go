@@ -0,0 +1,51 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// removableDevices lists mounted partitions whose backing block device
+// reports itself removable via /sys/block/<dev>/removable, the one reliable
+// signal on Linux ("mounted under /mnt or /media" just means an automounter
+// decided to put it there, and plenty of non-removable drives do too).
+func removableDevices() ([]removableDevice, error) {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return nil, fmt.Errorf("error detecting devices: %v", err)
+	}
+
+	var devices []removableDevice
+	for _, p := range partitions {
+		dev := blockDeviceName(p.Device)
+		flag, err := os.ReadFile(filepath.Join("/sys/block", dev, "removable"))
+		if err != nil || strings.TrimSpace(string(flag)) != "1" {
+			continue
+		}
+		devices = append(devices, removableDevice{
+			Mountpoint: p.Mountpoint,
+			Label:      filepath.Base(p.Mountpoint),
+		})
+	}
+	return devices, nil
+}
+
+// blockDeviceName maps a partition device node such as /dev/sdb1,
+// /dev/mmcblk0p1, or /dev/nvme0n1p1 to the parent block device name
+// (sdb, mmcblk0, nvme0n1) /sys/block entries are keyed by.
+func blockDeviceName(partition string) string {
+	name := strings.TrimPrefix(partition, "/dev/")
+	if strings.HasPrefix(name, "mmcblk") || strings.HasPrefix(name, "nvme") {
+		if idx := strings.LastIndex(name, "p"); idx > 0 {
+			return name[:idx]
+		}
+		return name
+	}
+	return strings.TrimRight(name, "0123456789")
+}
@@ -0,0 +1,24 @@
+//go:build linux
+
+package main
+
+import "testing"
+
+func TestBlockDeviceName(t *testing.T) {
+	cases := []struct {
+		partition string
+		want      string
+	}{
+		{"/dev/sdb1", "sdb"},
+		{"/dev/sdb15", "sdb"},
+		{"/dev/mmcblk0p1", "mmcblk0"},
+		{"/dev/mmcblk1p12", "mmcblk1"},
+		{"/dev/nvme0n1p1", "nvme0n1"},
+		{"/dev/nvme1n1p2", "nvme1n1"},
+	}
+	for _, c := range cases {
+		if got := blockDeviceName(c.partition); got != c.want {
+			t.Errorf("blockDeviceName(%q) = %q, want %q", c.partition, got, c.want)
+		}
+	}
+}
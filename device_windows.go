@@ -0,0 +1,49 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// removableDevices enumerates drive letters and keeps the ones
+// GetDriveTypeW reports as DRIVE_REMOVABLE. The Fstype field gopsutil
+// reports is a filesystem name (FAT32, NTFS, ...), never "removable", so it
+// can't be used to answer this question at all.
+func removableDevices() ([]removableDevice, error) {
+	mask, err := windows.GetLogicalDrives()
+	if err != nil {
+		return nil, fmt.Errorf("error enumerating drives: %v", err)
+	}
+
+	var devices []removableDevice
+	for i := 0; i < 26; i++ {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		root := string(rune('A'+i)) + `:\`
+		rootPtr, err := windows.UTF16PtrFromString(root)
+		if err != nil {
+			continue
+		}
+		if windows.GetDriveType(rootPtr) != windows.DRIVE_REMOVABLE {
+			continue
+		}
+
+		devices = append(devices, removableDevice{Mountpoint: root, Label: volumeLabel(rootPtr)})
+	}
+	return devices, nil
+}
+
+// volumeLabel reads the volume name Windows shows in Explorer, so -label can
+// select a card by the name it was formatted with (e.g. "GOPRO").
+func volumeLabel(rootPtr *uint16) string {
+	var nameBuf [windows.MAX_PATH + 1]uint16
+	if err := windows.GetVolumeInformation(rootPtr, &nameBuf[0], uint32(len(nameBuf)), nil, nil, nil, nil, 0); err != nil {
+		return ""
+	}
+	return windows.UTF16ToString(nameBuf[:])
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"lukechampine.com/blake3"
+)
+
+// chunkSize bounds how much of a file GenerateHash holds in memory at once,
+// so hashing a 4K clip off an SD card doesn't balloon RSS.
+const chunkSize = 1 << 20 // 1 MiB
+
+// GenerateHash streams path through the configured -hash algorithm in
+// chunkSize chunks and returns the hex digest.
+func GenerateHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var hasher hash.Hash
+	switch hashAlgo {
+	case "", "md5":
+		hasher = md5.New()
+	case "sha256":
+		hasher = sha256.New()
+	case "blake3":
+		hasher = blake3.New(32, nil)
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm: %s", hashAlgo)
+	}
+
+	buf := make([]byte, chunkSize)
+	if _, err := io.CopyBuffer(hasher, file, buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// hashedFile is one video candidate and its computed hash, or the error
+// encountered while hashing it.
+type hashedFile struct {
+	info os.FileInfo
+	hash string
+	err  error
+}
+
+// DetectNewVideos walks videoDir and hashes every candidate video file,
+// fanning the work out across -jobs hasher goroutines so a directory full of
+// large clips doesn't hash one file at a time in the main goroutine. Results
+// whose hash already has a mapping entry, in any state, are filtered out;
+// entries left mid-pipeline by an interrupted run are not returned here
+// (Store.Resumable returns those for -resume).
+func DetectNewVideos(mapping Mapping, videoDir string) ([]Video, error) {
+	// Every hash already in the mapping — terminal or not — is excluded from
+	// the walk, even under -resume: non-terminal entries are reintroduced by
+	// store.Resumable() instead, so a file stuck "failed" or "uploading"
+	// isn't processed twice (once as a "new" rehash, once as a resume).
+	existingHashes := make(map[string]bool, len(mapping.Videos))
+	for _, video := range mapping.Videos {
+		existingHashes[video.Hash] = true
+	}
+
+	paths := make(chan string)
+	results := make(chan hashedFile)
+
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = filepath.Walk(videoDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				// Don't re-ingest our own output: "archive" holds videos
+				// ProcessLocalStorage already archived, and "transcoded"
+				// holds prepareUploadSource's web-MP4 copies.
+				if path != videoDir && (info.Name() == "archive" || info.Name() == "transcoded") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if isVideoFile(info.Name()) {
+				paths <- path
+			}
+			return nil
+		})
+	}()
+
+	workers := jobs
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				info, err := os.Stat(path)
+				if err != nil {
+					results <- hashedFile{err: err}
+					continue
+				}
+				h, err := GenerateHash(path)
+				results <- hashedFile{info: info, hash: h, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Keep ranging over results, even after the first error, until it's
+	// closed: results and paths are unbuffered, so returning early here
+	// would leave any still-running hasher goroutine (and a walker blocked
+	// sending into paths) permanently parked trying to send with nothing
+	// left to receive.
+	var newVideos []Video
+	var firstErr error
+	for res := range results {
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+		if firstErr != nil {
+			continue
+		}
+		if existingHashes[res.hash] {
+			continue
+		}
+		existingHashes[res.hash] = true
+		newVideos = append(newVideos, Video{
+			Filename: res.info.Name(),
+			Hash:     res.hash,
+			State:    StateHashed,
+		})
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return newVideos, walkErr
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func writeVideoFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+}
+
+func TestDetectNewVideosDedupsAndSkipsOutputDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeVideoFile(t, dir, "a.mp4", "video-a")
+	writeVideoFile(t, dir, "b.mov", "video-b")
+	writeVideoFile(t, dir, "notes.txt", "not a video")
+
+	for _, sub := range []string{"archive", "transcoded"} {
+		subdir := filepath.Join(dir, sub)
+		if err := os.Mkdir(subdir, 0755); err != nil {
+			t.Fatalf("mkdir %s: %v", sub, err)
+		}
+		writeVideoFile(t, subdir, "c.mp4", "should not be ingested")
+	}
+
+	videos, err := DetectNewVideos(Mapping{}, dir)
+	if err != nil {
+		t.Fatalf("DetectNewVideos: %v", err)
+	}
+
+	var names []string
+	for _, v := range videos {
+		names = append(names, v.Filename)
+		if v.State != StateHashed {
+			t.Errorf("video %s: state = %q, want %q", v.Filename, v.State, StateHashed)
+		}
+	}
+	sort.Strings(names)
+	want := []string{"a.mp4", "b.mov"}
+	if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+		t.Fatalf("DetectNewVideos filenames = %v, want %v", names, want)
+	}
+
+	// A hash already present in the mapping (whatever its state) must not
+	// be re-surfaced as a "new" video.
+	aHash, err := GenerateHash(filepath.Join(dir, "a.mp4"))
+	if err != nil {
+		t.Fatalf("GenerateHash: %v", err)
+	}
+	mapping := Mapping{Videos: []Video{{Filename: "a.mp4", Hash: aHash, State: StateUploading}}}
+
+	videos, err = DetectNewVideos(mapping, dir)
+	if err != nil {
+		t.Fatalf("DetectNewVideos: %v", err)
+	}
+	for _, v := range videos {
+		if v.Filename == "a.mp4" {
+			t.Errorf("DetectNewVideos re-surfaced a.mp4, which already has a mapping entry")
+		}
+	}
+}
+
+func TestDetectNewVideosDrainsOnHashError(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.mp4", "c.mp4", "d.mp4"} {
+		writeVideoFile(t, dir, name, "content-"+name)
+	}
+	// A broken symlink looks like a video file to the walker but fails
+	// os.Stat, giving DetectNewVideos a guaranteed hashing error without
+	// blocking the whole run on other in-flight hashers.
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "b.mp4")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := DetectNewVideos(Mapping{}, dir); err == nil {
+			t.Error("DetectNewVideos: expected an error from the unreadable file")
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("DetectNewVideos did not return; hasher/walker goroutines likely deadlocked")
+	}
+}
@@ -1,37 +1,51 @@
 package main
 
 import (
-	"crypto/md5"
-	"encoding/hex"
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/charmbracelet/bubbletea"
-	"github.com/shirou/gopsutil/disk"
+	"github.com/Cdaprod/local-docker-detect-videos-app/storage"
+	"github.com/Cdaprod/local-docker-detect-videos-app/transcode"
+
+	// Registers their storage.Uploader factories via init(); add an import
+	// here for every backend that should be selectable through -storage.
+	_ "github.com/Cdaprod/local-docker-detect-videos-app/backends/youtube"
 )
 
 // Configuration flags
 var (
-	jsonFile     string
-	overrideDir  string
-	storageMode  string
-	cleanLocal   bool
-	showProgress bool
+	jsonFile      string
+	overrideDir   string
+	storageMode   string
+	cleanLocal    bool
+	showProgress  bool
+	hashAlgo      string
+	jobs          int
+	transcodeMode string
+	resumeRun     bool
+	deviceLabel   string
+	watchMode     bool
 )
 
 // Video represents a video entry in the mapping file
 type Video struct {
-	Filename       string `json:"filename"`
-	Hash           string `json:"hash"`
-	UploadStatus   string `json:"upload_status"`
+	Filename        string `json:"filename"`
+	Hash            string `json:"hash"`
+	State           string `json:"state"`
 	UploadTimestamp string `json:"upload_timestamp,omitempty"`
+	RemoteID        string `json:"remote_id,omitempty"`
+	RemoteURL       string `json:"remote_url,omitempty"`
+	Width           int    `json:"width,omitempty"`
+	Height          int    `json:"height,omitempty"`
+	DurationMs      int64  `json:"duration_ms,omitempty"`
+	Codec           string `json:"codec,omitempty"`
 }
 
 // Mapping represents the JSON structure
@@ -42,106 +56,15 @@ type Mapping struct {
 func init() {
 	flag.StringVar(&jsonFile, "json", "video_mapping.json", "Path to the JSON mapping file")
 	flag.StringVar(&overrideDir, "dir", "", "Manually specify the video directory (overrides device detection)")
-	flag.StringVar(&storageMode, "storage", "local", "Storage mode: 'local' or 'icloud'")
+	flag.StringVar(&storageMode, "storage", "local", "Storage destination: 'local' to archive in place, or a URL such as s3://bucket, gs://bucket, file:///path, azblob://container, icloud://folder, or youtube://channel")
 	flag.BoolVar(&cleanLocal, "clean", false, "Delete local files after upload")
 	flag.BoolVar(&showProgress, "progress", false, "Show progress using TUI (Bubbletea)")
-}
-
-// LoadMapping loads the JSON file into a Mapping struct
-func LoadMapping() (Mapping, error) {
-	var mapping Mapping
-	if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
-		file, _ := os.Create(jsonFile)
-		defer file.Close()
-		json.NewEncoder(file).Encode(mapping)
-	}
-	file, err := os.Open(jsonFile)
-	if err != nil {
-		return mapping, err
-	}
-	defer file.Close()
-
-	err = json.NewDecoder(file).Decode(&mapping)
-	return mapping, err
-}
-
-// SaveMapping saves the Mapping struct back to the JSON file
-func SaveMapping(mapping Mapping) error {
-	file, err := os.Create(jsonFile)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	return json.NewEncoder(file).Encode(mapping)
-}
-
-// DetectDevice finds the first removable device (cross-platform)
-func DetectDevice() (string, error) {
-	partitions, err := disk.Partitions(false)
-	if err != nil {
-		return "", fmt.Errorf("error detecting devices: %v", err)
-	}
-
-	for _, partition := range partitions {
-		if runtime.GOOS == "windows" {
-			if strings.Contains(strings.ToLower(partition.Fstype), "removable") {
-				return partition.Mountpoint, nil
-			}
-		} else if runtime.GOOS == "linux" {
-			if strings.HasPrefix(partition.Mountpoint, "/mnt") || strings.HasPrefix(partition.Mountpoint, "/media") {
-				return partition.Mountpoint, nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("no removable device detected")
-}
-
-// GenerateHash computes the MD5 hash of a file
-func GenerateHash(filepath string) (string, error) {
-	file, err := os.Open(filepath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	hasher := md5.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(hasher.Sum(nil)), nil
-}
-
-// DetectNewVideos detects videos not already in the mapping
-func DetectNewVideos(mapping Mapping, videoDir string) ([]Video, error) {
-	existingHashes := make(map[string]bool)
-	for _, video := range mapping.Videos {
-		existingHashes[video.Hash] = true
-	}
-
-	var newVideos []Video
-	err := filepath.Walk(videoDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && isVideoFile(info.Name()) {
-			hash, err := GenerateHash(path)
-			if err != nil {
-				return err
-			}
-			if !existingHashes[hash] {
-				newVideos = append(newVideos, Video{
-					Filename:       info.Name(),
-					Hash:           hash,
-					UploadStatus:   "pending",
-					UploadTimestamp: "",
-				})
-			}
-		}
-		return nil
-	})
-	return newVideos, err
+	flag.StringVar(&hashAlgo, "hash", "md5", "Hash algorithm for identifying videos: md5, sha256, or blake3")
+	flag.IntVar(&jobs, "jobs", runtime.NumCPU(), "Number of concurrent hashing/upload workers")
+	flag.StringVar(&transcodeMode, "transcode", "", "Transcode footage before upload: '' (off) or 'web' for an H.264/AAC MP4")
+	flag.BoolVar(&resumeRun, "resume", false, "Reprocess mapping entries left in a non-terminal state by a previous, interrupted run")
+	flag.StringVar(&deviceLabel, "label", "", "Only use a removable volume with this label (e.g. GOPRO)")
+	flag.BoolVar(&watchMode, "watch", false, "Block until a matching removable device is mounted, instead of exiting immediately")
 }
 
 // isVideoFile checks if a file has a video extension
@@ -170,54 +93,71 @@ func ProcessLocalStorage(videoDir string, video Video) error {
 		return fmt.Errorf("failed to move video to archive: %v", err)
 	}
 
-	fmt.Printf("Archived video: %s\n", video.Filename)
+	statusf("Archived video: %s\n", video.Filename)
 	return nil
 }
 
-// ProcessICloudStorage simulates uploading videos to iCloud
-func ProcessICloudStorage(videoDir string, video Video) error {
-	fmt.Printf("Uploading %s to iCloud...\n", video.Filename)
-	time.Sleep(2 * time.Second) // Simulate upload delay
-	if cleanLocal {
-		if err := os.Remove(filepath.Join(videoDir, video.Filename)); err != nil {
-			return fmt.Errorf("failed to delete local video after iCloud upload: %v", err)
-		}
-		fmt.Printf("Deleted local video: %s\n", video.Filename)
+// prepareUploadSource returns the path that should be uploaded for video:
+// the original file, or, when -transcode=web is set, a transcoded web-ready
+// copy. The original filename/hash stays the identity key in both cases, so
+// re-running the pipeline after a crash doesn't re-transcode anything
+// transcode.ToWeb already produced.
+func prepareUploadSource(ctx context.Context, videoDir string, video *Video) (string, error) {
+	sourcePath := filepath.Join(videoDir, video.Filename)
+	if transcodeMode != "web" {
+		return sourcePath, nil
 	}
-	return nil
-}
 
-// Main Bubbletea model for progress bar
-type model struct {
-	total   int
-	current int
-	quitting bool
+	ext := filepath.Ext(video.Filename)
+	destPath := filepath.Join(videoDir, "transcoded", strings.TrimSuffix(video.Filename, ext)+"-web.mp4")
+
+	if err := transcode.ToWeb(ctx, sourcePath, destPath); err != nil {
+		return "", err
+	}
+	info, err := transcode.Probe(ctx, destPath)
+	if err != nil {
+		return "", err
+	}
+	video.Width = info.Width
+	video.Height = info.Height
+	video.DurationMs = info.DurationMs
+	video.Codec = info.Codec
+
+	return destPath, nil
 }
 
-func (m model) Init() bubbletea.Cmd { return nil }
+// ProcessRemoteStorage uploads sourcePath (the original file, or its
+// transcoded-for-web copy when -transcode=web is set) through the given
+// storage.Uploader, recording the returned ETag/MD5 on the Video for later
+// verification.
+func ProcessRemoteStorage(ctx context.Context, uploader storage.Uploader, videoDir, sourcePath string, video *Video) error {
+	etag, err := uploader.Upload(ctx, sourcePath, video.Filename)
+	if err != nil {
+		return fmt.Errorf("failed to upload video: %v", err)
+	}
+	video.RemoteID = etag
+	if locator, ok := uploader.(storage.Locator); ok {
+		video.RemoteURL = locator.RemoteURL(etag)
+	}
+	statusf("Uploaded video: %s\n", video.Filename)
 
-func (m model) Update(msg bubbletea.Msg) (bubbletea.Model, bubbletea.Cmd) {
-	switch msg := msg.(type) {
-	case bubbletea.KeyMsg:
-		if msg.String() == "q" {
-			m.quitting = true
-			return m, bubbletea.Quit
-		}
-	case bubbletea.TickMsg:
-		if m.current < m.total {
-			m.current++
-			return m, bubbletea.Tick(time.Second)
+	if cleanLocal {
+		// -clean is about freeing space on the card, so it must remove the
+		// original file regardless of -transcode=web having uploaded a
+		// transcoded copy from sourcePath instead.
+		originalPath := filepath.Join(videoDir, video.Filename)
+		if err := os.Remove(originalPath); err != nil {
+			return fmt.Errorf("failed to delete local video after upload: %v", err)
 		}
-	}
-	return m, nil
-}
+		statusf("Deleted local video: %s\n", video.Filename)
 
-func (m model) View() string {
-	if m.quitting {
-		return "Goodbye!\n"
+		if sourcePath != originalPath {
+			if err := os.Remove(sourcePath); err != nil {
+				statusf("Warning: failed to delete transcoded temp file %s: %v\n", sourcePath, err)
+			}
+		}
 	}
-	progress := float64(m.current) / float64(m.total) * 100
-	return fmt.Sprintf("Progress: [%.2f%%]\nPress q to quit.", progress)
+	return nil
 }
 
 func main() {
@@ -227,7 +167,11 @@ func main() {
 	videoDir := overrideDir
 	if videoDir == "" {
 		var err error
-		videoDir, err = DetectDevice()
+		if watchMode {
+			videoDir, err = WatchForDevice(context.Background(), 2*time.Second)
+		} else {
+			videoDir, err = DetectDevice()
+		}
 		if err != nil {
 			fmt.Printf("Error detecting device: %v\n", err)
 			return
@@ -235,53 +179,126 @@ func main() {
 	}
 	fmt.Printf("Using video directory: %s\n", videoDir)
 
-	// Load mapping
-	mapping, err := LoadMapping()
+	// Open the mapping store. This both loads the existing mapping and takes
+	// an exclusive lock on it for the rest of the run.
+	store, err := OpenStore(jsonFile)
 	if err != nil {
-		fmt.Printf("Error loading mapping: %v\n", err)
+		fmt.Printf("Error opening mapping store: %v\n", err)
 		return
 	}
+	defer store.Close()
 
-	// Detect new videos
-	newVideos, err := DetectNewVideos(mapping, videoDir)
+	// Detect new videos, plus anything -resume says to retry
+	newVideos, err := DetectNewVideos(store.Mapping(), videoDir)
 	if err != nil {
 		fmt.Printf("Error detecting new videos: %v\n", err)
 		return
 	}
+	for _, video := range newVideos {
+		if err := store.Transition(video); err != nil {
+			fmt.Printf("Error persisting state for %s: %v\n", video.Filename, err)
+		}
+	}
+	if resumeRun {
+		newVideos = append(newVideos, store.Resumable()...)
+	}
 
-	// Display progress with Bubbletea if enabled
+	// Wire upload progress into whichever UI -progress calls for, so the
+	// uploader goroutines below can publish real events instead of a
+	// cosmetic ticker.
+	var progressDone chan struct{}
 	if showProgress {
-		p := bubbletea.NewProgram(model{total: len(newVideos)})
-		if err := p.Start(); err != nil {
-			fmt.Printf("Error running TUI: %v\n", err)
+		storage.OnStart = func(name string, total int64) {
+			progressEvents <- fileStartedMsg{name: name, total: total}
+		}
+		storage.OnProgress = func(name string, transferred int64) {
+			progressEvents <- fileProgressMsg{name: name, bytes: transferred}
+		}
+		storage.OnDone = func(name string, err error) {
+			progressEvents <- fileDoneMsg{name: name, err: err}
 		}
-	}
 
-	// Process each video
-	for _, video := range newVideos {
-		switch storageMode {
-		case "local":
-			err = ProcessLocalStorage(videoDir, video)
-		case "icloud":
-			err = ProcessICloudStorage(videoDir, video)
-		default:
-			fmt.Printf("Invalid storage mode: %s\n", storageMode)
-			return
+		progressDone = make(chan struct{})
+		if isTTY() {
+			// The TUI repaints the terminal from here on, so every status
+			// line below must go through statusf instead of straight to
+			// stdout to avoid colliding with its cursor control.
+			tuiOwnsStdout = true
+			go runTUI(progressDone)
+		} else {
+			go runPlainProgress(progressDone)
 		}
+	}
 
+	// Open the remote uploader once and reuse it for every file, unless
+	// we're just archiving in place.
+	ctx := context.Background()
+	var uploader storage.Uploader
+	if storageMode != "local" {
+		uploader, err = storage.Open(ctx, storageMode)
 		if err != nil {
-			fmt.Printf("Failed to process video: %s (%v)\n", video.Filename, err)
-			continue
+			fmt.Printf("Error opening storage backend %q: %v\n", storageMode, err)
+			return
 		}
+		defer uploader.Close()
+	}
 
-		// Mark video as uploaded
-		video.UploadStatus = "uploaded"
-		video.UploadTimestamp = time.Now().Format(time.RFC3339)
-		mapping.Videos = append(mapping.Videos, video)
+	// Process each video. Uploads run concurrently, bounded by -jobs, so a
+	// slow network upload doesn't stall the rest of the batch.
+	uploadJobs := jobs
+	if uploadJobs < 1 {
+		uploadJobs = 1
 	}
+	sem := make(chan struct{}, uploadJobs)
+	var wg sync.WaitGroup
 
-	// Save updated mapping
-	if err := SaveMapping(mapping); err != nil {
-		fmt.Printf("Error saving mapping: %v\n", err)
+	for _, video := range newVideos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(video Video) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			video.State = StateUploading
+			if err := store.Transition(video); err != nil {
+				statusf("Error persisting state for %s: %v\n", video.Filename, err)
+			}
+
+			var perr error
+			if storageMode == "local" {
+				perr = ProcessLocalStorage(videoDir, video)
+			} else {
+				var src string
+				src, perr = prepareUploadSource(ctx, videoDir, &video)
+				if perr == nil {
+					perr = ProcessRemoteStorage(ctx, uploader, videoDir, src, &video)
+				}
+			}
+
+			if perr != nil {
+				statusf("Failed to process video: %s (%v)\n", video.Filename, perr)
+				video.State = StateFailed
+				if err := store.Transition(video); err != nil {
+					statusf("Error persisting state for %s: %v\n", video.Filename, err)
+				}
+				return
+			}
+
+			if storageMode == "local" {
+				video.State = StateArchived
+			} else {
+				video.State = StateUploaded
+			}
+			video.UploadTimestamp = time.Now().Format(time.RFC3339)
+			if err := store.Transition(video); err != nil {
+				statusf("Error persisting state for %s: %v\n", video.Filename, err)
+			}
+		}(video)
+	}
+	wg.Wait()
+
+	if showProgress {
+		close(progressEvents)
+		<-progressDone
 	}
 }
\ No newline at end of file
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Video lifecycle states. Every transition is persisted immediately via
+// Store.Transition, so a crash mid-run never leaves an entry claiming
+// progress it didn't actually make.
+const (
+	StateDiscovered = "discovered"
+	StateHashed     = "hashed"
+	StateUploading  = "uploading"
+	StateUploaded   = "uploaded"
+	StateArchived   = "archived"
+	StateFailed     = "failed"
+)
+
+// terminalStates are states Store.Resumable treats as already handled.
+var terminalStates = map[string]bool{
+	StateUploaded: true,
+	StateArchived: true,
+}
+
+// Store is a crash-safe, single-writer handle on the JSON mapping file. It
+// holds an exclusive lock on jsonFile+".lock" for the life of the process so
+// two instances can't run against the same mapping concurrently, and every
+// Transition rewrites the file via a temp-file-plus-rename so a crash
+// mid-write never corrupts it.
+type Store struct {
+	path string
+	lock *os.File
+
+	mu      sync.Mutex
+	mapping Mapping
+}
+
+// OpenStore loads path (starting from an empty mapping if it doesn't exist
+// yet; the file is first written by Transition) and locks it for exclusive
+// use for the lifetime of the process.
+func OpenStore(path string) (*Store, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("mapping: opening lock file: %w", err)
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("mapping: another instance is already running against %s: %w", path, err)
+	}
+
+	s := &Store{path: path, lock: lock}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		lock.Close()
+		return nil, fmt.Errorf("mapping: reading %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.mapping); err != nil {
+			lock.Close()
+			return nil, fmt.Errorf("mapping: parsing %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Mapping returns a snapshot of the current mapping: what was loaded at Open
+// time plus every Transition made since.
+func (s *Store) Mapping() Mapping {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Mapping{Videos: append([]Video(nil), s.mapping.Videos...)}
+}
+
+// Resumable returns every video not yet in a terminal state, for -resume.
+func (s *Store) Resumable() []Video {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var videos []Video
+	for _, v := range s.mapping.Videos {
+		if !terminalStates[v.State] {
+			videos = append(videos, v)
+		}
+	}
+	return videos
+}
+
+// Transition upserts video (matched by Hash) and persists the whole mapping
+// atomically. Call it after every state change so the file on disk never
+// lags behind reality.
+func (s *Store) Transition(video Video) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	for i, existing := range s.mapping.Videos {
+		if existing.Hash == video.Hash {
+			s.mapping.Videos[i] = video
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.mapping.Videos = append(s.mapping.Videos, video)
+	}
+	return s.save()
+}
+
+// save writes the mapping to a temp file and renames it over path, so a
+// process killed mid-write leaves the previous, still-valid file in place.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("mapping: writing %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("mapping: renaming %s to %s: %w", tmp, s.path, err)
+	}
+	return nil
+}
+
+// Close releases the exclusive lock on the mapping file.
+func (s *Store) Close() error {
+	defer os.Remove(s.lock.Name())
+	return s.lock.Close()
+}
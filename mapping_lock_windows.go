@@ -0,0 +1,20 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes a non-blocking exclusive lock on f, failing immediately if
+// another process already holds it.
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(
+		windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, 1, 0,
+		&windows.Overlapped{},
+	)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func openTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStoreTransitionUpsertsByHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	s := openTestStore(t, path)
+
+	v := Video{Filename: "a.mp4", Hash: "h1", State: StateUploading}
+	if err := s.Transition(v); err != nil {
+		t.Fatalf("Transition (insert): %v", err)
+	}
+	if got := s.Mapping().Videos; len(got) != 1 || got[0].State != StateUploading {
+		t.Fatalf("Mapping after insert = %+v", got)
+	}
+
+	v.State = StateUploaded
+	v.RemoteID = "remote-1"
+	if err := s.Transition(v); err != nil {
+		t.Fatalf("Transition (update): %v", err)
+	}
+
+	videos := s.Mapping().Videos
+	if len(videos) != 1 {
+		t.Fatalf("Transition appended instead of upserting: %+v", videos)
+	}
+	if videos[0].State != StateUploaded || videos[0].RemoteID != "remote-1" {
+		t.Fatalf("Transition did not update existing entry: %+v", videos[0])
+	}
+}
+
+func TestStoreTransitionPersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	s := openTestStore(t, path)
+
+	if err := s.Transition(Video{Filename: "a.mp4", Hash: "h1", State: StateArchived}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s2, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("re-OpenStore: %v", err)
+	}
+	defer s2.Close()
+
+	videos := s2.Mapping().Videos
+	if len(videos) != 1 || videos[0].Hash != "h1" || videos[0].State != StateArchived {
+		t.Fatalf("reopened mapping = %+v, want one archived h1 entry", videos)
+	}
+
+	// save's temp-file-plus-rename should leave no stray .tmp file behind.
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("leftover tmp file: stat err = %v", err)
+	}
+}
+
+func TestStoreResumableFiltersTerminalStates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	s := openTestStore(t, path)
+
+	videos := []Video{
+		{Filename: "a.mp4", Hash: "h-uploading", State: StateUploading},
+		{Filename: "b.mp4", Hash: "h-failed", State: StateFailed},
+		{Filename: "c.mp4", Hash: "h-uploaded", State: StateUploaded},
+		{Filename: "d.mp4", Hash: "h-archived", State: StateArchived},
+	}
+	for _, v := range videos {
+		if err := s.Transition(v); err != nil {
+			t.Fatalf("Transition(%s): %v", v.Filename, err)
+		}
+	}
+
+	resumable := s.Resumable()
+	got := make(map[string]bool, len(resumable))
+	for _, v := range resumable {
+		got[v.Hash] = true
+	}
+	want := map[string]bool{"h-uploading": true, "h-failed": true}
+	if len(got) != len(want) {
+		t.Fatalf("Resumable() = %v, want %v", got, want)
+	}
+	for h := range want {
+		if !got[h] {
+			t.Errorf("Resumable() missing %s", h)
+		}
+	}
+}
+
+func TestStoreTransitionConcurrentSafe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	s := openTestStore(t, path)
+
+	const n = 20
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			v := Video{Filename: "concurrent.mp4", Hash: "same-hash", State: StateUploading}
+			if err := s.Transition(v); err != nil {
+				t.Errorf("Transition: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	videos := s.Mapping().Videos
+	if len(videos) != 1 {
+		t.Fatalf("concurrent Transitions on the same hash produced %d entries, want 1", len(videos))
+	}
+}
+
+func TestOpenStoreRejectsSecondInstance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	s := openTestStore(t, path)
+
+	if _, err := OpenStore(path); err == nil {
+		t.Fatal("OpenStore succeeded against an already-locked mapping file")
+	}
+
+	_ = s
+}
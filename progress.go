@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fileStartedMsg announces that a transfer of total bytes for name has
+// begun.
+type fileStartedMsg struct {
+	name  string
+	total int64
+}
+
+// fileProgressMsg reports cumulative bytes transferred so far for name.
+type fileProgressMsg struct {
+	name  string
+	bytes int64
+}
+
+// fileDoneMsg announces that a transfer finished, successfully or not.
+type fileDoneMsg struct {
+	name string
+	err  error
+}
+
+// channelClosedMsg tells the TUI that progressEvents has been closed because
+// every upload has finished, so it should quit.
+type channelClosedMsg struct{}
+
+// logMsg is a plain status line routed through the TUI's scrolling log
+// instead of being written straight to stdout, where it would collide with
+// Bubbletea's cursor repositioning.
+type logMsg string
+
+// tuiOwnsStdout is set by main before launching any upload goroutines when
+// the real (TTY) TUI is about to take over stdout, so statusf knows to route
+// status lines through progressEvents instead of printing them directly.
+var tuiOwnsStdout bool
+
+// statusf reports a one-line status message: straight to stdout normally,
+// or into the TUI's scrolling log when the TUI owns the screen, so a raw
+// Printf from another goroutine never interleaves with Bubbletea's escape
+// sequences.
+func statusf(format string, args ...interface{}) {
+	line := fmt.Sprintf(format, args...)
+	if tuiOwnsStdout {
+		progressEvents <- logMsg(line)
+		return
+	}
+	fmt.Print(line)
+}
+
+// progressEvents is the channel the uploader goroutines publish progress
+// messages (and statusf log lines) to. Both the TUI and the plain-line fallback drain it.
+var progressEvents = make(chan tea.Msg, 64)
+
+func waitForEvent() tea.Msg {
+	msg, ok := <-progressEvents
+	if !ok {
+		return channelClosedMsg{}
+	}
+	return msg
+}
+
+// fileProgress tracks one in-flight transfer for the TUI.
+type fileProgress struct {
+	total   int64
+	current int64
+	err     error
+}
+
+// model is the Bubbletea model driving the progress TUI: a per-file progress
+// bar, overall throughput and ETA, and a scrolling log of finished
+// transfers.
+type model struct {
+	files     map[string]*fileProgress
+	order     []string
+	log       []string
+	startedAt time.Time
+	quitting  bool
+}
+
+func newModel() model {
+	return model{
+		files:     make(map[string]*fileProgress),
+		startedAt: time.Now(),
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return waitForEvent
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "q" || msg.String() == "ctrl+c" {
+			m.quitting = true
+			return m, tea.Quit
+		}
+
+	case channelClosedMsg:
+		m.quitting = true
+		return m, tea.Quit
+
+	case fileStartedMsg:
+		m.files[msg.name] = &fileProgress{total: msg.total}
+		m.order = append(m.order, msg.name)
+		return m, waitForEvent
+
+	case fileProgressMsg:
+		if f, ok := m.files[msg.name]; ok {
+			f.current = msg.bytes
+		}
+		return m, waitForEvent
+
+	case fileDoneMsg:
+		if f, ok := m.files[msg.name]; ok {
+			f.err = msg.err
+		}
+		status := "done"
+		if msg.err != nil {
+			status = fmt.Sprintf("failed: %v", msg.err)
+		}
+		m.log = append(m.log, fmt.Sprintf("%s: %s", msg.name, status))
+		return m, waitForEvent
+
+	case logMsg:
+		m.log = append(m.log, string(msg))
+		return m, waitForEvent
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	if m.quitting {
+		return "Goodbye!\n"
+	}
+
+	var b strings.Builder
+	var totalBytes, doneBytes int64
+
+	for _, name := range m.order {
+		f := m.files[name]
+		totalBytes += f.total
+		doneBytes += f.current
+
+		pct := 0.0
+		if f.total > 0 {
+			pct = float64(f.current) / float64(f.total) * 100
+		}
+		fmt.Fprintf(&b, "%-30s [%-20s] %6.2f%%\n", name, progressBar(pct, 20), pct)
+	}
+
+	elapsed := time.Since(m.startedAt).Seconds()
+	var throughput float64
+	if elapsed > 0 {
+		throughput = float64(doneBytes) / elapsed
+	}
+	var eta time.Duration
+	if throughput > 0 && totalBytes > doneBytes {
+		eta = time.Duration(float64(totalBytes-doneBytes)/throughput) * time.Second
+	}
+	fmt.Fprintf(&b, "\n%.2f MB/s, ETA %s\n", throughput/1_000_000, eta.Round(time.Second))
+
+	if len(m.log) > 0 {
+		b.WriteString("\n")
+		for _, line := range m.log {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	b.WriteString("\nPress q to quit.")
+	return b.String()
+}
+
+func progressBar(pct float64, width int) string {
+	filled := int(pct / 100 * float64(width))
+	if filled > width {
+		filled = width
+	}
+	return strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+}
+
+// runTUI drives the Bubbletea progress UI until progressEvents is closed,
+// then signals done.
+func runTUI(done chan<- struct{}) {
+	defer close(done)
+	p := tea.NewProgram(newModel(), tea.WithAltScreen())
+	if err := p.Start(); err != nil {
+		fmt.Printf("Error running TUI: %v\n", err)
+	}
+}
+
+// runPlainProgress is the non-TTY fallback: plain start/done lines instead
+// of the interactive TUI, for piped output or CI logs.
+func runPlainProgress(done chan<- struct{}) {
+	defer close(done)
+	for msg := range progressEvents {
+		switch m := msg.(type) {
+		case fileStartedMsg:
+			fmt.Printf("start: %s (%d bytes)\n", m.name, m.total)
+		case fileDoneMsg:
+			if m.err != nil {
+				fmt.Printf("fail:  %s (%v)\n", m.name, m.err)
+			} else {
+				fmt.Printf("done:  %s\n", m.name)
+			}
+		}
+	}
+}
+
+// isTTY reports whether stdout is a terminal, so main can fall back to plain
+// log lines when it isn't.
+func isTTY() bool {
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,8 @@
+package storage
+
+// Locator is an optional extension an Uploader can implement to expose a
+// human-browsable URL for whatever identifier Upload returned. Backends that
+// have no such concept (plain object storage) simply don't implement it.
+type Locator interface {
+	RemoteURL(id string) string
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"io"
+	"os"
+)
+
+// Progress hooks let a caller (e.g. a TUI) observe transfer progress without
+// coupling every backend to a particular UI. All three are optional; a nil
+// hook is simply skipped, so upload code pays nothing when nobody's
+// watching.
+var (
+	OnStart    func(name string, total int64)
+	OnProgress func(name string, transferred int64)
+	OnDone     func(name string, err error)
+)
+
+// progressChunk bounds how often OnProgress fires: at most once per MiB
+// transferred, so a fast local upload doesn't flood the UI with events.
+const progressChunk = 1 << 20
+
+// progressWriter accumulates bytes written to it and reports cumulative
+// progress through OnProgress every progressChunk bytes.
+type progressWriter struct {
+	name        string
+	total       int64
+	transferred int64
+	sinceReport int64
+}
+
+func newProgressWriter(name string, total int64) *progressWriter {
+	return &progressWriter{name: name, total: total}
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	w.transferred += int64(n)
+	w.sinceReport += int64(n)
+	if w.sinceReport >= progressChunk || w.transferred == w.total {
+		OnProgress(w.name, w.transferred)
+		w.sinceReport = 0
+	}
+	return n, nil
+}
+
+// InstrumentReader wraps r in an io.TeeReader that reports cumulative
+// progress for name through OnProgress as it's read. It's a no-op, returning
+// r unchanged, when no OnProgress hook is registered.
+func InstrumentReader(name string, total int64, r io.Reader) io.Reader {
+	if OnProgress == nil {
+		return r
+	}
+	return io.TeeReader(r, newProgressWriter(name, total))
+}
+
+// fileSize stats path for its size, used to report upload totals.
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
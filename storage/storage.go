@@ -0,0 +1,99 @@
+// Package storage provides a pluggable Uploader abstraction so the main
+// pipeline can target local disk, cloud object storage, or WebDAV-backed
+// services without caring which one it's talking to. Backends register
+// themselves against a URL scheme (s3, gs, azblob, file, webdav, icloud, ...)
+// from an init() func, keeping the call site a single storage.Open(url) away
+// from any particular provider.
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Uploader uploads a local file to a remote destination identified by key
+// and returns an identifier (ETag/MD5) that can be used to verify the
+// upload later.
+type Uploader interface {
+	Upload(ctx context.Context, localPath, key string) (etag string, err error)
+	Close() error
+}
+
+// Factory constructs an Uploader for a parsed destination URL.
+type Factory func(ctx context.Context, u *url.URL) (Uploader, error)
+
+var registry = map[string]Factory{}
+
+// Register adds a Factory for the given URL scheme. Backends call this from
+// an init() func so selecting -storage=<scheme>://... just works.
+func Register(scheme string, f Factory) {
+	registry[scheme] = f
+}
+
+// Open parses rawURL and returns the Uploader registered for its scheme.
+func Open(ctx context.Context, rawURL string) (Uploader, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("storage: parse %q: %w", rawURL, err)
+	}
+	f, ok := registry[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("storage: no backend registered for scheme %q", u.Scheme)
+	}
+	return f(ctx, u)
+}
+
+// ContentType guesses a MIME type from a file extension, defaulting to
+// application/octet-stream when unknown.
+func ContentType(name string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// WithRetry runs op up to attempts times with exponential backoff, returning
+// the last error if every attempt fails. It gives up immediately if ctx is
+// canceled between attempts.
+func WithRetry(ctx context.Context, attempts int, op func() error) error {
+	var err error
+	backoff := 500 * time.Millisecond
+	for i := 0; i < attempts; i++ {
+		if err = op(); err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("storage: giving up after %d attempts: %w", attempts, err)
+}
+
+// md5File hashes a file on disk without buffering it in memory.
+func md5File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
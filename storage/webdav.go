@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/studio-b12/gowebdav"
+)
+
+func init() {
+	Register("webdav", openWebDAV)
+	Register("icloud", openWebDAV)
+}
+
+// webdavUploader uploads over WebDAV, the transport iCloud Drive exposes to
+// third-party clients. An -storage=icloud://<folder> destination is resolved
+// to https://www.icloud.com/<folder> and authenticated via ICLOUD_USER /
+// ICLOUD_APP_PASSWORD so the account password itself never touches argv.
+type webdavUploader struct {
+	client *gowebdav.Client
+}
+
+func openWebDAV(ctx context.Context, u *url.URL) (Uploader, error) {
+	endpoint := *u
+	if endpoint.Scheme == "icloud" {
+		// The host segment of an icloud:// URL is actually the destination
+		// folder (e.g. icloud://MyVideos), not a real hostname, so fold it
+		// into the path before rewriting the host to the real iCloud
+		// WebDAV endpoint.
+		endpoint.Path = path.Join("/", endpoint.Host, endpoint.Path)
+		endpoint.Scheme = "https"
+		endpoint.Host = "www.icloud.com"
+	}
+
+	user := os.Getenv("ICLOUD_USER")
+	pass := os.Getenv("ICLOUD_APP_PASSWORD")
+	client := gowebdav.NewClient(endpoint.String(), user, pass)
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("storage: webdav connect to %q: %w", endpoint.String(), err)
+	}
+	return &webdavUploader{client: client}, nil
+}
+
+func (w *webdavUploader) Upload(ctx context.Context, localPath, key string) (string, error) {
+	etag, err := md5File(localPath)
+	if err != nil {
+		return "", err
+	}
+
+	size, err := fileSize(localPath)
+	if err != nil {
+		return "", err
+	}
+	if OnStart != nil {
+		OnStart(key, size)
+	}
+
+	err = WithRetry(ctx, 5, func() error {
+		f, err := os.Open(localPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return w.client.WriteStream(key, InstrumentReader(key, size, f), 0644)
+	})
+	if OnDone != nil {
+		OnDone(key, err)
+	}
+	return etag, err
+}
+
+func (w *webdavUploader) Close() error {
+	return nil
+}
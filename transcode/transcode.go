@@ -0,0 +1,112 @@
+// Package transcode turns raw camera footage into a web-friendly MP4 using
+// the ffmpeg/ffprobe binaries, which must be available on PATH.
+package transcode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// Info holds the media properties ffprobe reports for a file.
+type Info struct {
+	Width      int
+	Height     int
+	DurationMs int64
+	Codec      string
+}
+
+// Probe shells out to ffprobe and extracts the fields the pipeline records
+// on a Video after transcoding.
+func Probe(ctx context.Context, path string) (Info, error) {
+	var info Info
+
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format", "-show_streams",
+		path,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return info, fmt.Errorf("transcode: ffprobe %s: %w", path, err)
+	}
+
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return info, fmt.Errorf("transcode: parsing ffprobe output for %s: %w", path, err)
+	}
+
+	for _, s := range probe.Streams {
+		if s.CodecType == "video" {
+			info.Width = s.Width
+			info.Height = s.Height
+			info.Codec = s.CodecName
+			break
+		}
+	}
+	if seconds, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.DurationMs = int64(seconds * 1000)
+	}
+	return info, nil
+}
+
+// ToWeb transcodes src into an H.264/AAC MP4 at dest suitable for web
+// playback. It's a no-op if dest already exists so re-running the pipeline
+// after a crash doesn't redo the work.
+func ToWeb(ctx context.Context, src, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-y", "-i", src,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23",
+		"-c:a", "aac", "-b:a", "128k",
+		"-movflags", "+faststart",
+		dest,
+	)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("transcode: ffmpeg %s: %w", src, err)
+	}
+	return nil
+}
+
+// Thumbnail extracts a single frame from src as a JPEG poster image at dest.
+func Thumbnail(ctx context.Context, src, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-y", "-ss", "00:00:01", "-i", src,
+		"-frames:v", "1",
+		dest,
+	)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("transcode: ffmpeg thumbnail %s: %w", src, err)
+	}
+	return nil
+}